@@ -0,0 +1,45 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dep
+
+import (
+	"github.com/crossplane/crossplane/apis/pkg/v1beta1"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// Cmd groups the xpkg dependency subcommands.
+type Cmd struct {
+	Sbom    sbomCmd    `cmd:"" help:"Generate a CycloneDX SBOM for a package and its transitive dependencies."`
+	Resolve resolveCmd `cmd:"" help:"Resolve a package and its transitive dependencies, honoring the configured license policy."`
+}
+
+// parseDependency parses a CLI package argument of the form
+// <repository>[:<tag>|@<digest>], e.g.
+// xpkg.upbound.io/crossplane/provider-aws:v0.1.0, into a v1beta1.Dependency
+// with the repository and version split apart. Every caller downstream of
+// the Manager (finalizeDepVersion, the image.Resolver) assumes d.Package is
+// a bare repository reference and d.Constraints carries the version, so the
+// raw arg can't be passed through as Package as-is.
+func parseDependency(pkg string) (v1beta1.Dependency, error) {
+	ref, err := name.ParseReference(pkg)
+	if err != nil {
+		return v1beta1.Dependency{}, err
+	}
+
+	return v1beta1.Dependency{
+		Package:     ref.Context().Name(),
+		Constraints: ref.Identifier(),
+	}, nil
+}
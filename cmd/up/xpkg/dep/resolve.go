@@ -0,0 +1,86 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/upbound/up/internal/upbound"
+	"github.com/upbound/up/internal/xpkg/dep/manager"
+	"github.com/upbound/up/internal/xpkg/dep/policy"
+)
+
+// resolveCmd resolves a package and its transitive dependencies, honoring
+// the excludes, pins, and license policy configured in .up/deps.yaml.
+type resolveCmd struct {
+	Package string `arg:"" help:"Package to resolve, e.g. xpkg.upbound.io/crossplane/provider-aws:v0.1.0."`
+
+	PolicyFile     string `default:".up/deps.yaml" help:"Path to the resolve policy file."`
+	IgnorePolicy   bool   `help:"Resolve even if the package or its dependencies violate the configured license policy."`
+	RegistryConfig string `optional:"" help:"Path to a registries.yaml describing registry mirrors and per-host auth/TLS to use when resolving."`
+}
+
+// Run executes the resolve command.
+func (c *resolveCmd) Run(upCtx *upbound.Context) error {
+	opts := []manager.Option{manager.WithIgnorePolicy(c.IgnorePolicy)}
+
+	if pol, err := policy.Load(c.PolicyFile); err == nil {
+		opts = append(opts, manager.WithPolicy(pol))
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if c.RegistryConfig != "" {
+		opts = append(opts, manager.WithRegistryConfig(c.RegistryConfig))
+	}
+
+	m, err := manager.New(opts...)
+	if err != nil {
+		return err
+	}
+
+	d, err := parseDependency(c.Package)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = m.Resolve(context.Background(), d)
+
+	var verr *policy.ViolationError
+	if ok := asViolationError(err, &verr); ok {
+		printViolations(verr)
+		return err
+	}
+
+	return err
+}
+
+// asViolationError reports whether err is (or wraps) a *policy.ViolationError.
+func asViolationError(err error, target **policy.ViolationError) bool {
+	return errors.As(err, target)
+}
+
+func printViolations(verr *policy.ViolationError) {
+	w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tLICENSE") // nolint:errcheck
+	for _, v := range verr.Violations {
+		fmt.Fprintf(w, "%s\t%s\n", v.Package, v.License) // nolint:errcheck
+	}
+	w.Flush() // nolint:errcheck
+}
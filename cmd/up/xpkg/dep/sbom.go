@@ -0,0 +1,68 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dep
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+
+	"github.com/crossplane/crossplane/apis/pkg/v1beta1"
+
+	"github.com/upbound/up/internal/upbound"
+	"github.com/upbound/up/internal/xpkg/dep/manager"
+)
+
+// sbomCmd generates a CycloneDX SBOM for a package and its transitive
+// dependencies.
+type sbomCmd struct {
+	Package string `arg:"" help:"Package to generate an SBOM for, e.g. xpkg.upbound.io/crossplane/provider-aws:v0.1.0."`
+
+	Format string `default:"json" enum:"json,xml" help:"Output format for the SBOM, one of json or xml."`
+}
+
+// Run executes the sbom command.
+func (c *sbomCmd) Run(upCtx *upbound.Context) error {
+	m, err := manager.New()
+	if err != nil {
+		return err
+	}
+
+	d, err := parseDependency(c.Package)
+	if err != nil {
+		return err
+	}
+
+	bom, err := m.SBOM(context.Background(), []v1beta1.Dependency{d})
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+	switch c.Format {
+	case "xml":
+		b, err = xml.MarshalIndent(bom, "", "  ")
+	default:
+		b, err = json.MarshalIndent(bom, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(os.Stdout, string(b))
+	return err
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache provides an on-disk cache of resolved xpkg packages and
+// their derived license scans, so that a Manager doesn't need to re-pull an
+// image it has already resolved in a prior process.
+package cache
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/crossplane/crossplane/apis/pkg/v1beta1"
+
+	"github.com/upbound/up/internal/xpkg/dep/license/spdx"
+	"github.com/upbound/up/internal/xpkg/dep/marshaler/xpkg"
+)
+
+// Local is an on-disk cache.Local rooted at a directory under the user's
+// cache directory. Packages are keyed by package@version; license scan
+// results are kept alongside them, keyed by image digest, so that a digest
+// scanned once is never re-derived by a later invocation.
+type Local struct {
+	dir string
+}
+
+// NewLocal returns a Local cache rooted under the user's cache directory
+// (see os.UserCacheDir), creating it if it does not already exist.
+func NewLocal() (*Local, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(base, "up", "xpkg")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &Local{dir: dir}, nil
+}
+
+// Store persists p to the cache under the key derived from d.
+func (l *Local) Store(d v1beta1.Dependency, p *xpkg.ParsedPackage) error {
+	f, err := os.Create(l.packagePath(d))
+	if err != nil {
+		return err
+	}
+	defer f.Close() // nolint:errcheck
+
+	return gob.NewEncoder(f).Encode(p)
+}
+
+// Get returns the package previously stored for d, or an error satisfying
+// os.IsNotExist if d has never been cached.
+func (l *Local) Get(d v1beta1.Dependency) (*xpkg.ParsedPackage, error) {
+	f, err := os.Open(l.packagePath(d))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint:errcheck
+
+	p := &xpkg.ParsedPackage{}
+	if err := gob.NewDecoder(f).Decode(p); err != nil {
+		return nil, fmt.Errorf("decoding cached package for %s: %w", d.Package, err)
+	}
+
+	return p, nil
+}
+
+// StoreLicenses persists the license scan result for digest, alongside the
+// package cached by Store, so a later process can reuse it instead of
+// re-pulling the image just to re-derive it.
+func (l *Local) StoreLicenses(digest string, licenses []spdx.License) error {
+	b, err := json.Marshal(licenses)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(l.licensesPath(digest), b, 0o640)
+}
+
+// GetLicenses returns the license scan result previously stored for digest,
+// or an error satisfying os.IsNotExist if digest has never been scanned.
+func (l *Local) GetLicenses(digest string) ([]spdx.License, error) {
+	b, err := os.ReadFile(l.licensesPath(digest))
+	if err != nil {
+		return nil, err
+	}
+
+	var licenses []spdx.License
+	if err := json.Unmarshal(b, &licenses); err != nil {
+		return nil, fmt.Errorf("decoding cached licenses for %s: %w", digest, err)
+	}
+
+	return licenses, nil
+}
+
+func (l *Local) packagePath(d v1beta1.Dependency) string {
+	return filepath.Join(l.dir, sanitize(d.Package+"@"+d.Constraints)+".gob")
+}
+
+func (l *Local) licensesPath(digest string) string {
+	return filepath.Join(l.dir, sanitize(digest)+".licenses.json")
+}
+
+// sanitize replaces path-unsafe characters in key so it can be used as a
+// file name.
+func sanitize(key string) string {
+	r := strings.NewReplacer("/", "_", ":", "_", "@", "_")
+	return r.Replace(key)
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package license scans xpkg OCI images for bundled license text and
+// normalizes what it finds to SPDX identifiers.
+package license
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"path"
+	"regexp"
+	"sync"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/upbound/up/internal/xpkg/dep/license/spdx"
+)
+
+// AnnotationKey is the meta.pkg.crossplane.io annotation a package author
+// can set to assert its license without relying on scanned file text.
+const AnnotationKey = "xpkg.upbound.io/license"
+
+// DefaultConfidenceThreshold is used when a Scanner is constructed without
+// an explicit WithConfidenceThreshold option.
+const DefaultConfidenceThreshold = 0.5
+
+var licenseFile = regexp.MustCompile(`(?i)^(license|licence|copying)(\..*)?$`)
+
+// Scanner detects SPDX licenses bundled in xpkg OCI layers. A single
+// Scanner is intended to be constructed once and reused across every
+// package a Manager resolves, so that repeated resolution of the same
+// digest does not re-scan its layers.
+type Scanner struct {
+	threshold float64
+
+	mu    sync.RWMutex
+	cache map[string][]spdx.License
+}
+
+// ScannerOption configures a Scanner.
+type ScannerOption func(*Scanner)
+
+// WithConfidenceThreshold sets the minimum confidence, in [0,1], a scanned
+// match must clear to be reported rather than falling back to
+// spdx.NoAssertion.
+func WithConfidenceThreshold(t float64) ScannerOption {
+	return func(s *Scanner) {
+		s.threshold = t
+	}
+}
+
+// NewScanner returns a new Scanner.
+func NewScanner(opts ...ScannerOption) *Scanner {
+	s := &Scanner{
+		threshold: DefaultConfidenceThreshold,
+		cache:     make(map[string][]spdx.License),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// Scan returns the SPDX licenses detected in img, which is identified by
+// digest for caching purposes. annotations is the image's manifest
+// annotations, consulted for the AnnotationKey license override.
+func (s *Scanner) Scan(_ context.Context, digest string, img v1.Image, annotations map[string]string) ([]spdx.License, error) {
+	s.mu.RLock()
+	if cached, ok := s.cache[digest]; ok {
+		s.mu.RUnlock()
+		return cached, nil
+	}
+	s.mu.RUnlock()
+
+	var found []spdx.License
+
+	if v, ok := annotations[AnnotationKey]; ok && v != "" {
+		found = append(found, spdx.License{ID: v, Confidence: 1, Source: "annotation"})
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range layers {
+		matches, err := s.scanLayer(l)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, matches...)
+	}
+
+	found = normalize(found)
+	if len(found) == 0 {
+		found = []spdx.License{{ID: spdx.NoAssertion, Source: "none"}}
+	}
+
+	s.mu.Lock()
+	s.cache[digest] = found
+	s.mu.Unlock()
+
+	return found, nil
+}
+
+func (s *Scanner) scanLayer(l v1.Layer) ([]spdx.License, error) {
+	rc, err := l.Uncompressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close() // nolint:errcheck
+
+	var found []spdx.License
+
+	tr := tar.NewReader(rc)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !licenseFile.MatchString(path.Base(hdr.Name)) {
+			continue
+		}
+
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		id, confidence := spdx.Classify(string(b))
+		if confidence < s.threshold {
+			id, confidence = spdx.NoAssertion, 0
+		}
+		found = append(found, spdx.License{ID: id, Confidence: confidence, Source: hdr.Name})
+	}
+
+	return found, nil
+}
+
+// normalize dedupes licenses by SPDX ID, keeping the first (and therefore
+// highest-priority, since the annotation override is always scanned first)
+// match for each identifier.
+func normalize(licenses []spdx.License) []spdx.License {
+	seen := make(map[string]struct{}, len(licenses))
+	out := make([]spdx.License, 0, len(licenses))
+	for _, l := range licenses {
+		if _, ok := seen[l.ID]; ok {
+			continue
+		}
+		seen[l.ID] = struct{}{}
+		out = append(out, l)
+	}
+	return out
+}
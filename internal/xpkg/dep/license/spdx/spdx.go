@@ -0,0 +1,84 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package spdx provides a minimal bundled SPDX license corpus used to
+// classify license text found in xpkg OCI layers.
+package spdx
+
+import "strings"
+
+// NoAssertion is the SPDX identifier used when no license match clears the
+// configured confidence threshold.
+const NoAssertion = "NOASSERTION"
+
+// License is a single SPDX license match, along with the confidence of the
+// match that produced it. Source records where the match was found, e.g. a
+// layer file path or "annotation".
+type License struct {
+	ID         string  `json:"id"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+}
+
+// entry is a single bundled corpus license, matched on any of its distinct
+// phrases appearing in a candidate text.
+type entry struct {
+	id      string
+	phrases []string
+}
+
+// corpus is a small bundled set of common OSS license identifiers. It is
+// intentionally not exhaustive; unmatched text falls back to NOASSERTION.
+var corpus = []entry{
+	{id: "Apache-2.0", phrases: []string{"apache license, version 2.0", "apache license version 2.0"}},
+	{id: "MIT", phrases: []string{"permission is hereby granted, free of charge"}},
+	// The 2-clause text also contains clause 2 ("redistributions in binary
+	// form must reproduce the above copyright"), so that sentence alone
+	// can't distinguish BSD-3-Clause from BSD-2-Clause. Match on the
+	// endorse-or-promote clause instead, since only the 3-clause text has it.
+	{id: "BSD-3-Clause", phrases: []string{"may be used to endorse or promote products derived from this software"}},
+	{id: "BSD-2-Clause", phrases: []string{"redistribution and use in source and binary forms"}},
+	{id: "MPL-2.0", phrases: []string{"mozilla public license, v. 2.0", "mozilla public license version 2.0"}},
+	// Every phrase here must be version-3-specific. A plain "gnu general
+	// public license" preamble phrase would also match GPL-2.0 text, which
+	// differs only in the "version 2"/"version 3" clause, so splitting that
+	// out as its own phrase let a GPL-2.0 file clear the confidence
+	// threshold as a false GPL-3.0-only match.
+	{id: "GPL-3.0-only", phrases: []string{"gnu general public license, version 3", "version 3 of the license, or (at your option) any later version"}},
+	{id: "ISC", phrases: []string{"permission to use, copy, modify, and/or distribute this software"}},
+}
+
+// Classify returns the SPDX identifier of the first corpus entry, in corpus
+// order, with at least one phrase found in the supplied text, and a
+// confidence in [0,1]: the fraction of that entry's phrases found. Later
+// entries are not considered once one matches. It returns NoAssertion with a
+// confidence of 0 when nothing in the corpus matches.
+func Classify(text string) (string, float64) {
+	norm := strings.ToLower(text)
+
+	for _, e := range corpus {
+		matched := 0
+		for _, phrase := range e.phrases {
+			if strings.Contains(norm, phrase) {
+				matched++
+			}
+		}
+		if matched == 0 {
+			continue
+		}
+		return e.id, float64(matched) / float64(len(e.phrases))
+	}
+
+	return NoAssertion, 0
+}
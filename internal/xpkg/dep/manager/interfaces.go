@@ -0,0 +1,64 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/crossplane/crossplane/apis/pkg/v1beta1"
+
+	"github.com/upbound/up/internal/xpkg/dep/license/spdx"
+	"github.com/upbound/up/internal/xpkg/dep/marshaler/xpkg"
+)
+
+// Cache stores and retrieves resolved packages and their derived license
+// scans. cache.Local is the on-disk implementation used by New.
+type Cache interface {
+	// Store persists p under the key derived from d.
+	Store(d v1beta1.Dependency, p *xpkg.ParsedPackage) error
+	// Get returns the package previously stored for d, or an error
+	// satisfying os.IsNotExist if d has never been cached.
+	Get(d v1beta1.Dependency) (*xpkg.ParsedPackage, error)
+	// StoreLicenses persists the license scan result for digest.
+	StoreLicenses(digest string, licenses []spdx.License) error
+	// GetLicenses returns the license scan result previously stored for
+	// digest, or an error satisfying os.IsNotExist if digest has never been
+	// scanned.
+	GetLicenses(digest string) ([]spdx.License, error)
+}
+
+// ImageResolver resolves a v1beta1.Dependency's version constraint to a
+// concrete tag, looks up its digest, and fetches the image itself.
+// image.Resolver is the implementation New constructs by default.
+type ImageResolver interface {
+	// ResolveTag resolves the version constraint on d to a concrete tag.
+	ResolveTag(ctx context.Context, d v1beta1.Dependency) (string, error)
+	// ResolveDigest resolves the image digest for d, whose Constraints must
+	// already be a concrete tag.
+	ResolveDigest(ctx context.Context, d v1beta1.Dependency) (string, error)
+	// ResolveImage resolves d (whose Constraints must already be a concrete
+	// tag) to the tag used and the fetched v1.Image.
+	ResolveImage(ctx context.Context, d v1beta1.Dependency) (string, v1.Image, error)
+}
+
+// XpkgMarshaler builds a ParsedPackage from a fetched image. xpkg.Marshaler
+// is the implementation New constructs by default.
+type XpkgMarshaler interface {
+	// FromImage parses the image at tag t for package pkg into a
+	// ParsedPackage.
+	FromImage(pkg, t string, i v1.Image) (*xpkg.ParsedPackage, error)
+}
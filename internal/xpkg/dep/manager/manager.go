@@ -16,24 +16,46 @@ package manager
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"runtime"
+	"sync"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 
 	"github.com/crossplane/crossplane/apis/pkg/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/kube-openapi/pkg/validation/validate"
 
 	"github.com/upbound/up/internal/xpkg/dep/cache"
+	"github.com/upbound/up/internal/xpkg/dep/license"
+	"github.com/upbound/up/internal/xpkg/dep/license/spdx"
 	"github.com/upbound/up/internal/xpkg/dep/marshaler/xpkg"
+	"github.com/upbound/up/internal/xpkg/dep/policy"
 	"github.com/upbound/up/internal/xpkg/dep/resolver/image"
+	"github.com/upbound/up/internal/xpkg/dep/sbom"
 )
 
 // Manager defines a dependency Manager
 type Manager struct {
-	c Cache
-	i ImageResolver
-	x XpkgMarshaler
-
-	acc []*xpkg.ParsedPackage
+	c   Cache
+	i   ImageResolver
+	x   XpkgMarshaler
+	lic *license.Scanner
+	pol *policy.Config
+
+	// ignorePolicy disables license policy enforcement in Resolve, for
+	// break-glass use via `--ignore-policy`.
+	ignorePolicy bool
+
+	// concurrency bounds the number of packages a single Resolve call will
+	// fetch at once.
+	concurrency int
+
+	// err records the first error encountered applying an Option, surfaced
+	// by New.
+	err error
 }
 
 // New returns a new Manager
@@ -50,14 +72,25 @@ func New(opts ...Option) (*Manager, error) {
 		return nil, err
 	}
 
-	m.i = image.NewResolver()
+	i, err := image.NewResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	m.i = i
 	m.c = c
 	m.x = x
-	m.acc = make([]*xpkg.ParsedPackage, 0)
+	// constructed once and reused across every addPkg call so that the same
+	// cached digest is never scanned for licenses more than once.
+	m.lic = license.NewScanner()
+	m.concurrency = runtime.GOMAXPROCS(0)
 
 	for _, o := range opts {
 		o(m)
 	}
+	if m.err != nil {
+		return nil, m.err
+	}
 
 	return m, nil
 }
@@ -79,22 +112,85 @@ func WithResolver(r ImageResolver) Option {
 	}
 }
 
+// WithLicenseScanner sets the supplied license.Scanner on the Manager.
+func WithLicenseScanner(s *license.Scanner) Option {
+	return func(m *Manager) {
+		m.lic = s
+	}
+}
+
+// WithRegistryConfig configures the Manager's image resolver to use the
+// registry mirrors and per-host auth/TLS defined in the registries.yaml at
+// path, so that `up xpkg dep` can transparently pull from an internal
+// mirror of xpkg.upbound.io.
+func WithRegistryConfig(path string) Option {
+	return func(m *Manager) {
+		i, err := image.NewResolver(image.WithRegistryConfigFile(path))
+		if err != nil {
+			m.err = err
+			return
+		}
+		m.i = i
+	}
+}
+
+// WithPolicy sets the supplied resolve policy on the Manager.
+func WithPolicy(p *policy.Config) Option {
+	return func(m *Manager) {
+		m.pol = p
+	}
+}
+
+// WithIgnorePolicy disables license policy enforcement in Resolve. It is
+// intended for break-glass use only.
+func WithIgnorePolicy(ignore bool) Option {
+	return func(m *Manager) {
+		m.ignorePolicy = ignore
+	}
+}
+
+// WithConcurrency bounds the number of packages a single Resolve call will
+// fetch at once. It defaults to runtime.GOMAXPROCS(0).
+func WithConcurrency(n int) Option {
+	return func(m *Manager) {
+		m.concurrency = n
+	}
+}
+
 // Snapshot returns a Snapshot containing a view of all of the validators for
 // dependencies (both defined and transitive) related to the given slice of
-// v1beta1.Dependency.
+// v1beta1.Dependency. The top-level dependencies are resolved concurrently.
 func (m *Manager) Snapshot(ctx context.Context, deps []v1beta1.Dependency) (*Snapshot, error) {
+	var mu sync.Mutex
 	view := make(map[schema.GroupVersionKind]*validate.SchemaValidator)
 
-	for _, d := range deps {
-		_, acc, err := m.Resolve(ctx, d)
+	var wg sync.WaitGroup
+	errs := make([]error, len(deps))
+	for i, d := range deps {
+		i, d := i, d
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, acc, err := m.Resolve(ctx, d)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, p := range acc {
+				for k, v := range p.Validators() {
+					view[k] = v
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
 		if err != nil {
 			return nil, err
 		}
-		for _, p := range acc {
-			for k, v := range p.Validators() {
-				view[k] = v
-			}
-		}
 	}
 
 	return &Snapshot{
@@ -102,54 +198,202 @@ func (m *Manager) Snapshot(ctx context.Context, deps []v1beta1.Dependency) (*Sna
 	}, nil
 }
 
-// Resolve resolves the given package as well as it's transitive dependencies.
-// If storage is successful, the resolved dependency is returned, errors
-// otherwise.
+// SBOM returns a CycloneDX 1.4 bill of materials describing every package
+// surfaced while resolving the supplied dependencies, including their
+// transitive dependency graph.
+func (m *Manager) SBOM(ctx context.Context, deps []v1beta1.Dependency) (*cyclonedx.BOM, error) {
+	g := sbom.NewGraph()
+
+	for _, d := range deps {
+		if err := m.addSBOMNode(ctx, d, g); err != nil {
+			return nil, err
+		}
+	}
+
+	return g.BOM(), nil
+}
+
+// addSBOMNode resolves the package backing d, records it as a component on
+// g, then recurses into its transitive dependencies. Already-visited
+// packages are skipped so that diamond dependencies only contribute a
+// single component.
+func (m *Manager) addSBOMNode(ctx context.Context, d v1beta1.Dependency, g *sbom.Graph) error {
+	if err := m.finalizeDepVersion(ctx, &d); err != nil {
+		return err
+	}
+
+	if g.Has(d) {
+		return nil
+	}
+
+	p, err := m.retrievePkg(ctx, d)
+	if err != nil {
+		return err
+	}
+	g.AddComponent(d, p)
+
+	for _, cd := range p.Dependencies() {
+		// AddEdge keys its bom-ref off cd.Constraints, so cd must be
+		// finalized here, before the edge is recorded — otherwise the edge
+		// references the child's raw constraint while the component added
+		// by the recursive call below is keyed by its finalized one, and
+		// the two never match.
+		if err := m.finalizeDepVersion(ctx, &cd); err != nil {
+			return err
+		}
+		g.AddEdge(d, cd)
+		if err := m.addSBOMNode(ctx, cd, g); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Resolve resolves the given package as well as it's transitive
+// dependencies, fanning out across the dependency DAG with a bounded pool
+// of workers. A dependency reached along more than one path is only ever
+// fetched once, and the first error encountered anywhere in the tree
+// cancels the rest of the resolution.
 func (m *Manager) Resolve(ctx context.Context, d v1beta1.Dependency) (v1beta1.Dependency, []*xpkg.ParsedPackage, error) {
 	ud := v1beta1.Dependency{}
 
-	e, err := m.retrievePkg(ctx, d)
+	st, cctx := newResolveState(ctx, m.concurrency)
+	e := m.resolveNode(cctx, st, d)
+
+	acc, err := st.result()
 	if err != nil {
-		return ud, m.acc, nil
+		return ud, acc, err
+	}
+	if e == nil {
+		// resolveNode bails out without recording an error as soon as it
+		// sees ctx already canceled/expired, so a caller passing in a dead
+		// context reaches here with both e and err nil. Report ctx's error
+		// rather than dereferencing a nil e below.
+		if cerr := cctx.Err(); cerr != nil {
+			return ud, acc, cerr
+		}
+		return ud, acc, fmt.Errorf("failed to resolve %s: no package returned", d.Package)
 	}
-	m.acc = append(m.acc, e)
 
-	// recursively resolve all transitive dependencies
-	// currently assumes we have something from
-	if err := m.resolveAllDeps(ctx, e); err != nil {
-		return ud, m.acc, err
+	if !m.ignorePolicy {
+		if err := m.checkLicensePolicy(acc); err != nil {
+			return ud, acc, err
+		}
 	}
 
 	ud.Type = e.Type()
 	ud.Package = d.Package
 	ud.Constraints = e.Version()
 
-	return ud, m.acc, nil
+	return ud, acc, nil
 }
 
-// resolveAllDeps recursively resolves the transitive dependencies for a
-// given Entry. In addition, resolveAllDeps takes an accumulator for gathering
-// the related xpkg.ParsedPackages for the dependency tree.
-func (m *Manager) resolveAllDeps(ctx context.Context, p *xpkg.ParsedPackage) error {
+// checkLicensePolicy evaluates every package in acc against the configured
+// license policy, returning a *policy.ViolationError enumerating every
+// offending package if any are found.
+func (m *Manager) checkLicensePolicy(acc []*xpkg.ParsedPackage) error {
+	if m.pol == nil {
+		return nil
+	}
+
+	var violations []policy.Violation
+	for _, p := range acc {
+		violations = append(violations, m.pol.CheckPackage(p.Digest(), p.Licenses())...)
+	}
+	if len(violations) > 0 {
+		return &policy.ViolationError{Violations: violations}
+	}
+
+	return nil
+}
 
-	if len(p.Dependencies()) == 0 {
-		// no remaining dependencies to resolve
+// resolveNode resolves d and, the first time d's package@version is seen
+// within st, recurses into its transitive dependencies in parallel. Later
+// arrivals at the same package@version block on the same sync.Once and
+// then return without doing any further work, so diamond dependencies only
+// ever contribute a single component to st's accumulator. It returns the
+// resolved package to the call that performed the resolution; it returns nil
+// if resolution failed (the error is available from st.result()) and to
+// every later arrival at an already-resolved package@version.
+//
+// The concurrency semaphore is only ever held around the retrievePkg call
+// itself, never across the recursive fan-out below it: a goroutine that held
+// its permit while blocked in wg.Wait() for a child's permit would deadlock
+// any chain deeper than the configured concurrency, since every held permit
+// would belong to a goroutine waiting on one more permit that can never free
+// up. Releasing before recursing means fan-out is unbounded (cheap) while
+// the actual fetches stay bounded.
+func (m *Manager) resolveNode(ctx context.Context, st *resolveState, d v1beta1.Dependency) *xpkg.ParsedPackage {
+	if ctx.Err() != nil {
 		return nil
 	}
 
-	for _, d := range p.Dependencies() {
-		e, err := m.retrievePkg(ctx, d)
+	if err := m.finalizeDepVersion(ctx, &d); err != nil {
+		st.fail(err)
+		return nil
+	}
+	key := d.Package + "@" + d.Constraints
+
+	var p *xpkg.ParsedPackage
+	st.onceFor(key).Do(func() {
+		if !st.acquire(ctx) {
+			// context was canceled (a sibling failed) while waiting for a
+			// concurrency slot; stop doing new work.
+			return
+		}
+		v, err, _ := st.sf.Do(key, func() (interface{}, error) {
+			return m.retrievePkg(ctx, d)
+		})
+		st.release()
 		if err != nil {
-			return err
+			st.fail(err)
+			return
 		}
-		m.acc = append(m.acc, e)
+		p = v.(*xpkg.ParsedPackage)
+		st.append(p)
+
+		var wg sync.WaitGroup
+		for _, cd := range p.Dependencies() {
+			if m.pol.Excluded(cd.Package, cd.Constraints) {
+				// a path-only exclude (or a version-scoped one whose raw
+				// constraint is already a concrete version) matches before
+				// finalizeDepVersion contacts the registry, so the dependency
+				// and its own transitive dependencies are skipped entirely
+				// rather than failing if it turns out to be unreachable.
+				continue
+			}
 
-		if err := m.resolveAllDeps(ctx, e); err != nil {
-			return err
+			// Excluded's version-scoped Constraint only ever matches a
+			// concrete version, so cd must be finalized before checking it
+			// again, not left as the child's raw, unresolved constraint
+			// string. A dependency matched only by such an exclude is
+			// therefore still resolved first, and fails if unreachable.
+			if err := m.finalizeDepVersion(ctx, &cd); err != nil {
+				st.fail(err)
+				break
+			}
+
+			if m.pol.Excluded(cd.Package, cd.Constraints) {
+				continue
+			}
+
+			if ctx.Err() != nil {
+				// a sibling failed; stop spawning new work.
+				break
+			}
+
+			cd := cd
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				m.resolveNode(ctx, st, cd)
+			}()
 		}
-	}
+		wg.Wait()
+	})
 
-	return nil
+	return p
 }
 
 func (m *Manager) addPkg(ctx context.Context, d v1beta1.Dependency) (*xpkg.ParsedPackage, error) {
@@ -164,6 +408,10 @@ func (m *Manager) addPkg(ctx context.Context, d v1beta1.Dependency) (*xpkg.Parse
 		return nil, err
 	}
 
+	if err := m.scanLicenses(ctx, p, i); err != nil {
+		return nil, err
+	}
+
 	// add xpkg to cache
 	err = m.c.Store(d, p)
 	if err != nil {
@@ -173,6 +421,46 @@ func (m *Manager) addPkg(ctx context.Context, d v1beta1.Dependency) (*xpkg.Parse
 	return p, nil
 }
 
+// scanLicenses scans i for license evidence and records the result against
+// p's digest, both in-process (see licenses.go) and persisted in cache.Local
+// keyed by digest, so a later process doesn't need to re-pull the image just
+// to re-derive a scan it's already done. This must be called for every
+// package surfaced by retrievePkg, not just ones freshly fetched by addPkg,
+// or a package that's merely warm in cache.Local would silently report no
+// licenses to checkLicensePolicy.
+func (m *Manager) scanLicenses(ctx context.Context, p *xpkg.ParsedPackage, i v1.Image) error {
+	annotations := map[string]string{}
+	if manifest, err := i.Manifest(); err == nil {
+		annotations = manifest.Annotations
+	}
+
+	found, err := m.lic.Scan(ctx, p.Digest(), i, annotations)
+	if err != nil {
+		return err
+	}
+	xpkg.SetLicenses(p.Digest(), found)
+
+	return m.c.StoreLicenses(p.Digest(), found)
+}
+
+// Licenses returns the SPDX licenses detected for every package surfaced
+// while resolving the supplied dependencies, keyed by image digest.
+func (m *Manager) Licenses(ctx context.Context, deps []v1beta1.Dependency) (map[string][]spdx.License, error) {
+	out := make(map[string][]spdx.License)
+
+	for _, d := range deps {
+		_, acc, err := m.Resolve(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range acc {
+			out[p.Digest()] = p.Licenses()
+		}
+	}
+
+	return out, nil
+}
+
 func (m *Manager) retrievePkg(ctx context.Context, d v1beta1.Dependency) (*xpkg.ParsedPackage, error) {
 	// resolve version prior to Get
 	if err := m.finalizeDepVersion(ctx, &d); err != nil {
@@ -203,6 +491,28 @@ func (m *Manager) retrievePkg(ctx context.Context, d v1beta1.Dependency) (*xpkg.
 			if err != nil {
 				return nil, err
 			}
+		} else if p.Licenses() == nil {
+			// warm cache hit: p itself is up to date, but in-process license
+			// results don't survive process restarts. Check the persisted
+			// scan in cache.Local before falling back to a full image pull
+			// just to re-derive what was already scanned in a prior
+			// invocation.
+			found, lerr := m.c.GetLicenses(p.Digest())
+			switch {
+			case lerr == nil:
+				xpkg.SetLicenses(p.Digest(), found)
+			case os.IsNotExist(lerr):
+				_, i, err := m.i.ResolveImage(ctx, d)
+				if err != nil {
+					return nil, err
+				}
+
+				if err := m.scanLicenses(ctx, p, i); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, lerr
+			}
 		}
 	}
 
@@ -211,6 +521,12 @@ func (m *Manager) retrievePkg(ctx context.Context, d v1beta1.Dependency) (*xpkg.
 
 // finalizeDepVersion sets the resolved tag version on the supplied v1beta1.Dependency.
 func (m *Manager) finalizeDepVersion(ctx context.Context, d *v1beta1.Dependency) error {
+	if v, ok := m.pol.Pin(d.Package); ok {
+		// a configured pin overrides upstream constraints entirely.
+		d.Constraints = v
+		return nil
+	}
+
 	// determine the version (using resolver) to use based on the supplied constraints
 	v, err := m.i.ResolveTag(ctx, *d)
 	if err != nil {
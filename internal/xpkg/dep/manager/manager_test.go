@@ -0,0 +1,79 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/crossplane/crossplane/apis/pkg/v1beta1"
+)
+
+// fakeResolver is a minimal ImageResolver whose ResolveTag always fails,
+// used to exercise resolveNode's finalizeDepVersion error path without
+// needing a real image.Resolver or network access.
+type fakeResolver struct {
+	resolveTagErr error
+}
+
+func (f *fakeResolver) ResolveTag(context.Context, v1beta1.Dependency) (string, error) {
+	return "", f.resolveTagErr
+}
+
+func (f *fakeResolver) ResolveDigest(context.Context, v1beta1.Dependency) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (f *fakeResolver) ResolveImage(context.Context, v1beta1.Dependency) (string, v1.Image, error) {
+	return "", nil, errors.New("not implemented")
+}
+
+// TestResolveNodePropagatesFinalizeVersionError confirms that a
+// finalizeDepVersion failure on the node itself (not a child) is recorded
+// via st.fail and resolveNode returns nil, rather than the error being lost.
+func TestResolveNodePropagatesFinalizeVersionError(t *testing.T) {
+	wantErr := errors.New("no tag satisfies constraint")
+	m := &Manager{i: &fakeResolver{resolveTagErr: wantErr}}
+
+	st, ctx := newResolveState(context.Background(), 2)
+	p := m.resolveNode(ctx, st, v1beta1.Dependency{Package: "xpkg.upbound.io/crossplane/provider-aws", Constraints: ">=v0.1.0"})
+
+	if p != nil {
+		t.Errorf("resolveNode() = %v, want nil", p)
+	}
+	if _, err := st.result(); !errors.Is(err, wantErr) {
+		t.Errorf("st.result() error = %v, want %v", err, wantErr)
+	}
+}
+
+// TestResolveSurfacesAlreadyDoneContext guards against a nil-pointer panic:
+// resolveNode's first line returns nil without ever calling st.fail when ctx
+// is already canceled/expired, so Resolve must check for that case itself
+// before dereferencing the *xpkg.ParsedPackage resolveNode returned, rather
+// than calling e.Type()/e.Version() on a nil e.
+func TestResolveSurfacesAlreadyDoneContext(t *testing.T) {
+	m := &Manager{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := m.Resolve(ctx, v1beta1.Dependency{Package: "xpkg.upbound.io/crossplane/provider-aws", Constraints: "v0.1.0"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Resolve() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/upbound/up/internal/xpkg/dep/marshaler/xpkg"
+)
+
+// resolveState is the concurrency-scoped state for a single Resolve call. It
+// is never shared across calls, which is what makes a Manager safe to reuse
+// across concurrent Resolve invocations.
+type resolveState struct {
+	// sem bounds the number of in-flight retrievePkg calls.
+	sem chan struct{}
+	// sf dedupes concurrent retrievePkg calls for the same package@version.
+	sf singleflight.Group
+
+	mu   sync.Mutex
+	once map[string]*sync.Once // package@version -> guards first resolution
+	acc  []*xpkg.ParsedPackage
+
+	errOnce sync.Once
+	err     error
+	cancel  context.CancelFunc
+}
+
+// newResolveState returns a resolveState bounding parallelism to
+// concurrency, along with a context that is canceled as soon as any node
+// fails, so that siblings of a failed node stop cleanly rather than
+// continuing to do wasted work.
+func newResolveState(ctx context.Context, concurrency int) (*resolveState, context.Context) {
+	cctx, cancel := context.WithCancel(ctx)
+	return &resolveState{
+		sem:    make(chan struct{}, concurrency),
+		once:   make(map[string]*sync.Once),
+		cancel: cancel,
+	}, cctx
+}
+
+// onceFor returns the sync.Once guarding the first resolution of key,
+// creating it if this is the first time key has been seen.
+func (s *resolveState) onceFor(key string) *sync.Once {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.once[key]
+	if !ok {
+		o = &sync.Once{}
+		s.once[key] = o
+	}
+	return o
+}
+
+// acquire blocks until a concurrency slot is available or ctx is done.
+func (s *resolveState) acquire(ctx context.Context) bool {
+	select {
+	case s.sem <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *resolveState) release() {
+	<-s.sem
+}
+
+// append records a resolved package in the shared accumulator.
+func (s *resolveState) append(p *xpkg.ParsedPackage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acc = append(s.acc, p)
+}
+
+// fail records err as the first error seen by this resolveState, if one
+// hasn't already been recorded, and cancels the shared context so that
+// sibling goroutines stop starting new work.
+func (s *resolveState) fail(err error) {
+	s.errOnce.Do(func() {
+		s.err = err
+		s.cancel()
+	})
+}
+
+// result returns the accumulated packages and the first error seen, if any.
+func (s *resolveState) result() ([]*xpkg.ParsedPackage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.acc, s.err
+}
@@ -0,0 +1,257 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/upbound/up/internal/xpkg/dep/marshaler/xpkg"
+)
+
+// buildTestGraph returns a synthetic dependency DAG of the given width and
+// depth, where every node at a given depth shares the same children (so,
+// for width > 1, every node below depth 1 is reached along more than one
+// path), along with the total number of distinct package@version keys in it
+// (1 + width*depth).
+func buildTestGraph(width, depth int) (map[string][]string, int) {
+	graph := make(map[string][]string)
+	seen := make(map[string]struct{})
+
+	var build func(prefix string, level int) string
+	build = func(prefix string, level int) string {
+		key := fmt.Sprintf("%s@v0.%d.0", prefix, level)
+		seen[key] = struct{}{}
+		if _, ok := graph[key]; ok || level == depth {
+			return key
+		}
+
+		children := make([]string, 0, width)
+		for i := 0; i < width; i++ {
+			children = append(children, build(fmt.Sprintf("pkg-%d", i), level+1))
+		}
+		graph[key] = children
+		return key
+	}
+	build("root", 0)
+
+	return graph, len(seen)
+}
+
+// walk fans out across graph starting at key using a resolveState exactly
+// as resolveNode does: each key is only ever resolved once (guarded by a
+// sync.Once), with the semaphore held only around the simulated "fetch"
+// (never across the recursive fan-out, which must stay unbounded so a
+// goroutine waiting on its children never sits on a held permit), and a
+// failure cancels ctx so siblings stop cleanly.
+func walk(ctx context.Context, st *resolveState, graph map[string][]string, key string, resolved *int64, fail string) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	st.onceFor(key).Do(func() {
+		if !st.acquire(ctx) {
+			return
+		}
+		atomic.AddInt64(resolved, 1)
+		isFail := key == fail
+		st.release()
+
+		if isFail {
+			st.fail(errors.New("boom"))
+			return
+		}
+		st.append((*xpkg.ParsedPackage)(nil))
+
+		var wg sync.WaitGroup
+		for _, child := range graph[key] {
+			child := child
+			if ctx.Err() != nil {
+				break
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				walk(ctx, st, graph, child, resolved, fail)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestResolveStateDedupesDiamondDependencies(t *testing.T) {
+	graph, total := buildTestGraph(3, 3)
+
+	st, ctx := newResolveState(context.Background(), 4)
+	var resolved int64
+	walk(ctx, st, graph, "root@v0.0.0", &resolved, "")
+
+	acc, err := st.result()
+	if err != nil {
+		t.Fatalf("result() error = %v", err)
+	}
+	if got := int(resolved); got != total {
+		t.Errorf("resolved %d distinct keys, want %d", got, total)
+	}
+	if len(acc) != total {
+		t.Errorf("accumulated %d packages, want %d", len(acc), total)
+	}
+}
+
+// TestResolveStateDeepChainDoesNotDeadlock guards against holding a
+// semaphore permit across a blocking wait for a child's permit: a chain
+// deeper than the configured concurrency deadlocks under that bug, because
+// every held permit ends up belonging to a goroutine blocked acquiring one
+// more permit that can never free up.
+func TestResolveStateDeepChainDoesNotDeadlock(t *testing.T) {
+	graph, total := buildTestGraph(1, 8)
+
+	st, ctx := newResolveState(context.Background(), 2)
+	var resolved int64
+
+	done := make(chan struct{})
+	go func() {
+		walk(ctx, st, graph, "root@v0.0.0", &resolved, "")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("walk did not complete within 3s: a goroutine is likely holding a semaphore permit while blocked waiting for a child's permit (deadlock)")
+	}
+
+	if got := int(resolved); got != total {
+		t.Errorf("resolved %d distinct keys, want %d", got, total)
+	}
+}
+
+func TestResolveStateFailCancelsSiblings(t *testing.T) {
+	graph, _ := buildTestGraph(5, 2)
+
+	st, ctx := newResolveState(context.Background(), 1)
+	var resolved int64
+	walk(ctx, st, graph, "root@v0.0.0", &resolved, "pkg-0@v0.1.0")
+
+	_, err := st.result()
+	if err == nil {
+		t.Fatal("result() error = nil, want the failure recorded by st.fail")
+	}
+	if ctx.Err() == nil {
+		t.Error("ctx.Err() = nil, want the shared context canceled after the failure")
+	}
+}
+
+func TestResolveStateFailOnlyRecordsFirstError(t *testing.T) {
+	st, _ := newResolveState(context.Background(), 4)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			st.fail(fmt.Errorf("error %d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	_, err := st.result()
+	if err == nil {
+		t.Fatal("expected an error to be recorded")
+	}
+
+	st.fail(errors.New("later error"))
+	if _, again := st.result(); again != err {
+		t.Errorf("result() error = %v after a later fail, want the first recorded error %v", again, err)
+	}
+}
+
+// walkWithWork is like walk but sleeps for work on every newly resolved
+// node, simulating the cost of an actual retrievePkg fetch, so that
+// resolving serially versus with concurrency produces a measurable wall
+// clock difference instead of both finishing near-instantly regardless of
+// concurrency.
+func walkWithWork(ctx context.Context, st *resolveState, graph map[string][]string, key string, work time.Duration) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	st.onceFor(key).Do(func() {
+		if !st.acquire(ctx) {
+			return
+		}
+		time.Sleep(work)
+		st.release()
+
+		st.append((*xpkg.ParsedPackage)(nil))
+
+		var wg sync.WaitGroup
+		for _, child := range graph[key] {
+			child := child
+			if ctx.Err() != nil {
+				break
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				walkWithWork(ctx, st, graph, child, work)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+// benchNodeWork simulates the per-package cost of a real retrievePkg fetch.
+const benchNodeWork = 2 * time.Millisecond
+
+// BenchmarkResolveStateFanOutConcurrent resolves a synthetic graph of ~50
+// transitive nodes (comparable to a Configuration pulling in ~50 transitive
+// Providers) through a resolveState with concurrency 8, exercising the same
+// dedup/semaphore/cancellation primitives resolveNode relies on. Compare
+// against BenchmarkResolveStateFanOutSerial (concurrency 1) to see the
+// speedup concurrent resolution gives on the same graph:
+//
+//	go test ./internal/xpkg/dep/manager/... -run '^$' -bench FanOut -benchtime 5x
+func BenchmarkResolveStateFanOutConcurrent(b *testing.B) {
+	graph, _ := buildTestGraph(7, 7) // 1 + 7*7 = 50 distinct nodes
+
+	for i := 0; i < b.N; i++ {
+		st, ctx := newResolveState(context.Background(), 8)
+		walkWithWork(ctx, st, graph, "root@v0.0.0", benchNodeWork)
+		if _, err := st.result(); err != nil {
+			b.Fatalf("result() error = %v", err)
+		}
+	}
+}
+
+// BenchmarkResolveStateFanOutSerial resolves the same ~50-node graph as
+// BenchmarkResolveStateFanOutConcurrent, but with concurrency 1, as the
+// serial-equivalent baseline to measure the concurrent speedup against.
+func BenchmarkResolveStateFanOutSerial(b *testing.B) {
+	graph, _ := buildTestGraph(7, 7)
+
+	for i := 0; i < b.N; i++ {
+		st, ctx := newResolveState(context.Background(), 1)
+		walkWithWork(ctx, st, graph, "root@v0.0.0", benchNodeWork)
+		if _, err := st.result(); err != nil {
+			b.Fatalf("result() error = %v", err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xpkg
+
+import (
+	"sync"
+
+	"github.com/upbound/up/internal/xpkg/dep/license/spdx"
+)
+
+// licenses holds the SPDX licenses detected for a ParsedPackage by a
+// license.Scanner, keyed by the package's image digest. It is independent
+// of a particular ParsedPackage value so that a result scanned once is
+// visible to every ParsedPackage instance sharing that digest, matching how
+// cache.Local itself is keyed. This is a process-lifetime cache of the
+// authoritative copy Manager persists in cache.Local; it only saves a
+// round-trip through the cache for repeated lookups within one process, and
+// is never itself the only copy of a scan result.
+var licenses = struct {
+	mu sync.RWMutex
+	m  map[string][]spdx.License
+}{m: make(map[string][]spdx.License)}
+
+// Licenses returns the SPDX licenses detected for this package, or nil if
+// it has not yet been scanned by a license.Scanner.
+func (p *ParsedPackage) Licenses() []spdx.License {
+	licenses.mu.RLock()
+	defer licenses.mu.RUnlock()
+	return licenses.m[p.Digest()]
+}
+
+// SetLicenses records the SPDX licenses detected for the package with the
+// given digest in this process's cache. It is called by Manager.scanLicenses
+// after scanning a package's image, and when loading a previously persisted
+// scan result back out of cache.Local on a warm hit in a new process.
+func SetLicenses(digest string, l []spdx.License) {
+	licenses.mu.Lock()
+	defer licenses.mu.Unlock()
+	licenses.m[digest] = l
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy defines a declarative resolve-time policy for the xpkg
+// dependency Manager: dependencies to exclude outright, version pins that
+// override upstream constraints, and an SPDX license allow/deny list.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/semver/v3"
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/internal/xpkg/dep/license/spdx"
+)
+
+// DefaultPath is where Load looks for a resolve policy when no explicit
+// path is supplied, relative to the project root.
+const DefaultPath = ".up/deps.yaml"
+
+// Exclude matches one or more dependency package paths by glob, optionally
+// narrowed to versions satisfying Constraint.
+type Exclude struct {
+	Path       string `json:"path"`
+	Constraint string `json:"constraint,omitempty"`
+}
+
+// Licenses configures the SPDX allow/deny list enforced against every
+// resolved package's detected licenses.
+type Licenses struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// Config is a resolve-time policy honored by manager.Manager.Resolve.
+type Config struct {
+	Excludes []Exclude         `json:"excludes,omitempty"`
+	Pins     map[string]string `json:"pins,omitempty"`
+	Licenses Licenses          `json:"licenses,omitempty"`
+}
+
+// Load reads and parses a Config from the YAML document at path, or at
+// DefaultPath if path is empty.
+func Load(path string) (*Config, error) {
+	if path == "" {
+		path = DefaultPath
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// Excluded reports whether the dependency package at the given version is
+// matched by one of the Config's Excludes.
+func (c *Config) Excluded(pkg, version string) bool {
+	if c == nil {
+		return false
+	}
+
+	for _, e := range c.Excludes {
+		ok, err := filepath.Match(e.Path, pkg)
+		if err != nil || !ok {
+			continue
+		}
+		if e.Constraint == "" {
+			return true
+		}
+		con, err := semver.NewConstraint(e.Constraint)
+		if err != nil {
+			continue
+		}
+		v, err := semver.NewVersion(version)
+		if err != nil {
+			continue
+		}
+		if con.Check(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Pin returns the pinned tag for pkg and true if one is configured.
+func (c *Config) Pin(pkg string) (string, bool) {
+	if c == nil || c.Pins == nil {
+		return "", false
+	}
+	v, ok := c.Pins[pkg]
+	return v, ok
+}
+
+// Violation records a single package whose detected license is not
+// permitted by the Config's Licenses allow/deny list.
+type Violation struct {
+	Package string
+	License string
+}
+
+// ViolationError is returned by manager.Manager.Resolve when one or more
+// resolved packages violate the configured license policy.
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	return fmt.Sprintf("%d package(s) violate the configured license policy", len(e.Violations))
+}
+
+// CheckLicense reports whether the given SPDX license identifier is
+// permitted by the Config's Licenses allow/deny list. An empty allow list
+// permits everything not explicitly denied.
+func (c *Config) CheckLicense(id string) bool {
+	if c == nil {
+		return true
+	}
+	for _, d := range c.Licenses.Deny {
+		if d == id {
+			return false
+		}
+	}
+	if len(c.Licenses.Allow) == 0 {
+		return true
+	}
+	for _, a := range c.Licenses.Allow {
+		if a == id {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPackage evaluates every license detected for a package against the
+// Config and appends a Violation for each one that isn't permitted.
+func (c *Config) CheckPackage(pkg string, licenses []spdx.License) []Violation {
+	var violations []Violation
+	for _, l := range licenses {
+		if !c.CheckLicense(l.ID) {
+			violations = append(violations, Violation{Package: pkg, License: l.ID})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import "testing"
+
+func TestExcludedVersionScoped(t *testing.T) {
+	c := &Config{
+		Excludes: []Exclude{
+			{Path: "xpkg.upbound.io/crossplane/provider-aws", Constraint: "<v0.2.0"},
+		},
+	}
+
+	cases := []struct {
+		name    string
+		pkg     string
+		version string
+		want    bool
+	}{
+		{"matches excluded version", "xpkg.upbound.io/crossplane/provider-aws", "v0.1.0", true},
+		{"version outside constraint", "xpkg.upbound.io/crossplane/provider-aws", "v0.3.0", false},
+		{"path does not match", "xpkg.upbound.io/crossplane/provider-gcp", "v0.1.0", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := c.Excluded(tc.pkg, tc.version); got != tc.want {
+				t.Errorf("Excluded(%q, %q) = %v, want %v", tc.pkg, tc.version, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExcludedPathOnly(t *testing.T) {
+	c := &Config{
+		Excludes: []Exclude{
+			{Path: "xpkg.upbound.io/crossplane/*"},
+		},
+	}
+
+	if !c.Excluded("xpkg.upbound.io/crossplane/provider-aws", "v1.0.0") {
+		t.Error("expected unconditional path-only exclude to match")
+	}
+}
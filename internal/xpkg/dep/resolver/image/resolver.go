@@ -0,0 +1,285 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package image resolves xpkg OCI references to tags, digests, and images,
+// transparently falling back across configured registry mirrors.
+package image
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+
+	"github.com/crossplane/crossplane/apis/pkg/v1beta1"
+)
+
+// Resolver resolves xpkg OCI references, consulting a RegistryConfig (if
+// any) for mirror endpoints and per-host auth/TLS.
+type Resolver struct {
+	reg *RegistryConfig
+	// err records the first error encountered applying an Option, surfaced
+	// by NewResolver.
+	err error
+
+	// endpointCache remembers, per host, the last mirror endpoint that
+	// successfully served a request, so repeated lookups for the same host
+	// (e.g. across a Snapshot call) don't re-probe a dead mirror first.
+	mu            sync.Mutex
+	endpointCache map[string]string
+}
+
+// Option configures a Resolver.
+type Option func(*Resolver)
+
+// WithRegistryConfig sets the supplied RegistryConfig on the Resolver.
+func WithRegistryConfig(cfg *RegistryConfig) Option {
+	return func(r *Resolver) {
+		r.reg = cfg
+	}
+}
+
+// WithRegistryConfigFile loads a RegistryConfig from the given
+// registries.yaml path and sets it on the Resolver. If the file cannot be
+// read or parsed, the error is recorded and returned by NewResolver.
+func WithRegistryConfigFile(path string) Option {
+	return func(r *Resolver) {
+		cfg, err := LoadRegistryConfig(path)
+		if err != nil {
+			r.err = err
+			return
+		}
+		r.reg = cfg
+	}
+}
+
+// NewResolver returns a new Resolver, or an error if an Option failed to
+// apply (e.g. WithRegistryConfigFile couldn't read its registries.yaml).
+func NewResolver(opts ...Option) (*Resolver, error) {
+	r := &Resolver{
+		endpointCache: make(map[string]string),
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r, nil
+}
+
+// ResolveTag resolves the version constraint on d to a concrete tag.
+func (r *Resolver) ResolveTag(ctx context.Context, d v1beta1.Dependency) (string, error) {
+	repo, err := name.NewRepository(d.Package)
+	if err != nil {
+		return "", err
+	}
+
+	con, err := semver.NewConstraint(d.Constraints)
+	if err != nil {
+		// not a constraint (e.g. an exact tag already); use it verbatim.
+		return d.Constraints, nil
+	}
+
+	var tags []string
+	if err := r.withEndpoint(ctx, repo, func(candidate name.Repository, opts []remote.Option) error {
+		var err error
+		tags, err = remote.List(candidate, opts...)
+		return err
+	}); err != nil {
+		return "", err
+	}
+
+	var best *semver.Version
+	var bestTag string
+	for _, t := range tags {
+		v, err := semver.NewVersion(t)
+		if err != nil {
+			continue
+		}
+		if !con.Check(v) {
+			continue
+		}
+		if best == nil || v.GreaterThan(best) {
+			best, bestTag = v, t
+		}
+	}
+	if best == nil {
+		return "", fmt.Errorf("no tag of %s satisfies constraint %q", d.Package, d.Constraints)
+	}
+
+	return bestTag, nil
+}
+
+// ResolveDigest resolves the image digest for d, whose Constraints must
+// already be a concrete tag.
+func (r *Resolver) ResolveDigest(ctx context.Context, d v1beta1.Dependency) (string, error) {
+	ref, err := name.ParseReference(fmt.Sprintf("%s:%s", d.Package, d.Constraints))
+	if err != nil {
+		return "", err
+	}
+
+	var digest string
+	err = r.withEndpoint(ctx, ref.Context(), func(candidate name.Repository, opts []remote.Option) error {
+		rewritten, err := rewriteReference(ref, candidate)
+		if err != nil {
+			return err
+		}
+		desc, err := remote.Get(rewritten, opts...)
+		if err != nil {
+			return err
+		}
+		digest = desc.Digest.String()
+		return nil
+	})
+
+	return digest, err
+}
+
+// ResolveImage resolves d (whose Constraints must already be a concrete
+// tag) to the tag used and the fetched v1.Image.
+func (r *Resolver) ResolveImage(ctx context.Context, d v1beta1.Dependency) (string, v1.Image, error) {
+	ref, err := name.ParseReference(fmt.Sprintf("%s:%s", d.Package, d.Constraints))
+	if err != nil {
+		return "", nil, err
+	}
+
+	var img v1.Image
+	err = r.withEndpoint(ctx, ref.Context(), func(candidate name.Repository, opts []remote.Option) error {
+		rewritten, err := rewriteReference(ref, candidate)
+		if err != nil {
+			return err
+		}
+		img, err = remote.Image(rewritten, opts...)
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return d.Constraints, img, nil
+}
+
+// withEndpoint calls fn once per candidate endpoint for repo's registry, in
+// order (preferring a previously-successful endpoint first), rewriting only
+// the registry portion of repo so its repository path survives every
+// candidate, including the original host itself. It stops at the first call
+// that doesn't return a retryable error.
+func (r *Resolver) withEndpoint(_ context.Context, repo name.Repository, fn func(repo name.Repository, opts []remote.Option) error) error {
+	host := repo.RegistryStr()
+	candidates := r.reg.endpoints(host)
+
+	r.mu.Lock()
+	if cached, ok := r.endpointCache[host]; ok {
+		candidates = moveToFront(candidates, cached)
+	}
+	r.mu.Unlock()
+
+	var lastErr error
+	for _, endpoint := range candidates {
+		candidate, err := name.NewRepository(endpoint + "/" + repo.RepositoryStr())
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		opts, err := r.transportOptions(endpoint)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if err := fn(candidate, opts); err != nil {
+			lastErr = err
+			if isRetryable(err) {
+				continue
+			}
+			return err
+		}
+
+		r.mu.Lock()
+		r.endpointCache[host] = endpoint
+		r.mu.Unlock()
+		return nil
+	}
+
+	return lastErr
+}
+
+func (r *Resolver) transportOptions(host string) ([]remote.Option, error) {
+	tlsCfg, err := r.reg.tlsConfig(host)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []remote.Option{remote.WithAuth(r.reg.authenticator(host))}
+	if tlsCfg != nil {
+		opts = append(opts, remote.WithTransport(&http.Transport{TLSClientConfig: tlsCfg}))
+	}
+
+	return opts, nil
+}
+
+// rewriteReference rewrites ref to use repo's registry and repository path,
+// preserving ref's tag or digest.
+func rewriteReference(ref name.Reference, repo name.Repository) (name.Reference, error) {
+	switch t := ref.(type) {
+	case name.Tag:
+		return name.NewTag(fmt.Sprintf("%s:%s", repo.String(), t.TagStr()))
+	case name.Digest:
+		return name.NewDigest(fmt.Sprintf("%s@%s", repo.String(), t.DigestStr()))
+	default:
+		return nil, fmt.Errorf("unsupported reference type %T", ref)
+	}
+}
+
+func moveToFront(candidates []string, v string) []string {
+	out := make([]string, 0, len(candidates))
+	out = append(out, v)
+	for _, c := range candidates {
+		if c != v {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// isRetryable reports whether err warrants falling back to the next
+// candidate mirror endpoint, i.e. a 404/5xx from the registry or a TLS
+// handshake failure.
+func isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return true
+	}
+
+	var terr *transport.Error
+	if errors.As(err, &terr) {
+		return terr.StatusCode == 404 || terr.StatusCode >= 500
+	}
+
+	return strings.Contains(err.Error(), "tls:")
+}
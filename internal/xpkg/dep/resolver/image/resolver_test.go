@@ -0,0 +1,103 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// errRetryable has a message isRetryable treats as retryable (a TLS
+// failure), so withEndpoint tries every candidate before giving up.
+var errRetryable = errors.New("tls: handshake failure")
+
+func TestWithEndpointPreservesRepositoryPath(t *testing.T) {
+	repo, err := name.NewRepository("xpkg.upbound.io/crossplane/provider-aws")
+	if err != nil {
+		t.Fatalf("NewRepository: %v", err)
+	}
+
+	r := &Resolver{
+		reg: &RegistryConfig{
+			Mirrors: map[string]MirrorConfig{
+				"xpkg.upbound.io": {Endpoint: []string{"mirror.example.com"}},
+			},
+		},
+		endpointCache: make(map[string]string),
+	}
+
+	var got []string
+	err = r.withEndpoint(context.Background(), repo, func(candidate name.Repository, _ []remote.Option) error {
+		got = append(got, candidate.String())
+		return errRetryable
+	})
+	if err != errRetryable {
+		t.Fatalf("withEndpoint error = %v, want errRetryable (all candidates exhausted)", err)
+	}
+
+	want := []string{
+		"mirror.example.com/crossplane/provider-aws",
+		"xpkg.upbound.io/crossplane/provider-aws",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("candidates = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("candidate[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWithEndpointNoMirrorFallsBackToHost(t *testing.T) {
+	repo, err := name.NewRepository("xpkg.upbound.io/crossplane/provider-aws")
+	if err != nil {
+		t.Fatalf("NewRepository: %v", err)
+	}
+
+	r := &Resolver{endpointCache: make(map[string]string)}
+
+	var got string
+	err = r.withEndpoint(context.Background(), repo, func(candidate name.Repository, _ []remote.Option) error {
+		got = candidate.String()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("withEndpoint: %v", err)
+	}
+
+	if want := "xpkg.upbound.io/crossplane/provider-aws"; got != want {
+		t.Errorf("candidate = %q, want %q", got, want)
+	}
+}
+
+// TestIsRetryableUnwrapsCertificateVerificationError guards against
+// regressing to a bare type assertion: remote.Get/remote.Image normally
+// wrap the underlying TLS error, so isRetryable must use errors.As (as it
+// already does for *transport.Error) rather than a direct assertion that
+// would never match a wrapped error.
+func TestIsRetryableUnwrapsCertificateVerificationError(t *testing.T) {
+	wrapped := fmt.Errorf("fetching manifest: %w", &tls.CertificateVerificationError{})
+
+	if !isRetryable(wrapped) {
+		t.Error("isRetryable(wrapped *tls.CertificateVerificationError) = false, want true")
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/crossplane/crossplane/apis/pkg/v1beta1"
+
+	"github.com/upbound/up/internal/xpkg/dep/marshaler/xpkg"
+)
+
+// TestBOMDependenciesAreSorted guards the Graph doc comment's claim that the
+// rendered document is "stable across repeated runs over the same resolved
+// graph": BOM built each Dependency's child list straight off a map, so
+// without a sort the order would vary run to run.
+func TestBOMDependenciesAreSorted(t *testing.T) {
+	parent := v1beta1.Dependency{Package: "xpkg.upbound.io/crossplane/parent", Constraints: "v1.0.0"}
+	children := []v1beta1.Dependency{
+		{Package: "xpkg.upbound.io/crossplane/zzz", Constraints: "v1.0.0"},
+		{Package: "xpkg.upbound.io/crossplane/aaa", Constraints: "v1.0.0"},
+		{Package: "xpkg.upbound.io/crossplane/mmm", Constraints: "v1.0.0"},
+	}
+
+	for i := 0; i < 10; i++ {
+		g := NewGraph()
+		g.AddComponent(parent, &xpkg.ParsedPackage{})
+		for _, c := range children {
+			g.AddComponent(c, &xpkg.ParsedPackage{})
+			g.AddEdge(parent, c)
+		}
+
+		bom := g.BOM()
+		found := false
+		for _, dep := range *bom.Dependencies {
+			if dep.Ref != bomRef(parent) {
+				continue
+			}
+			found = true
+			got := *dep.Dependencies
+			if !sort.StringsAreSorted(got) {
+				t.Fatalf("run %d: Dependencies = %v, want sorted", i, got)
+			}
+		}
+		if !found {
+			t.Fatalf("run %d: no Dependency entry for %s", i, bomRef(parent))
+		}
+	}
+}